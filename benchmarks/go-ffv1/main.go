@@ -52,7 +52,7 @@ func main() {
 			continue
 		}
 
-		_, err = d.DecodeFrame(packet.Data)
+		_, _, err = d.DecodeFrame(packet.Data)
 		if err != nil {
 			log.Fatalln(err)
 		}
@@ -1,7 +1,6 @@
 package main
 
 import (
-	"encoding/binary"
 	"fmt"
 	"io"
 	"log"
@@ -43,12 +42,14 @@ func main() {
 		log.Fatalln(err)
 	}
 
-	file, err := os.Create("data/ffv1-go.raw")
+	file, err := os.Create("data/ffv1-go.y4m")
 	if err != nil {
 		log.Fatalln(err)
 	}
 	defer file.Close()
 
+	var y4m *ffv1.Y4MWriter
+
 	for {
 		packet, err := mat.ReadPacket()
 		if err == io.EOF {
@@ -57,44 +58,25 @@ func main() {
 			log.Fatalln(err)
 		}
 
-		fmt.Printf("extradata = %d packet = %d track = %d\n\n", len(extradata), len(packet.Data), packet.Track)
 		if packet.Track != 0 {
 			continue
 		}
 
-		frame, err := d.DecodeFrame(packet.Data)
+		frame, _, err := d.DecodeFrame(packet.Data)
 		if err != nil {
 			log.Fatalln(err)
 		}
-		fmt.Printf("Frame decoded at %dx%d\n", frame.Width, frame.Height)
 
-		if frame.BitDepth == 8 {
-			err = binary.Write(file, binary.LittleEndian, frame.Buf[0])
-			if err != nil {
-				log.Fatalln(err)
-			}
-			err = binary.Write(file, binary.LittleEndian, frame.Buf[1])
-			if err != nil {
-				log.Fatalln(err)
-			}
-			err = binary.Write(file, binary.LittleEndian, frame.Buf[2])
-			if err != nil {
-				log.Fatalln(err)
-			}
-		} else {
-			err = binary.Write(file, binary.LittleEndian, frame.Buf16[0])
-			if err != nil {
-				log.Fatalln(err)
-			}
-			err = binary.Write(file, binary.LittleEndian, frame.Buf16[1])
-			if err != nil {
-				log.Fatalln(err)
-			}
-			err = binary.Write(file, binary.LittleEndian, frame.Buf16[2])
+		if y4m == nil {
+			y4m, err = ffv1.NewY4MWriter(file, ffv1.Y4MHeader{Width: frame.Width, Height: frame.Height})
 			if err != nil {
 				log.Fatalln(err)
 			}
 		}
+
+		if err := y4m.WriteFrame(frame); err != nil {
+			log.Fatalln(err)
+		}
 	}
 	fmt.Println("Done.")
 }
@@ -0,0 +1,168 @@
+package ffv1
+
+import (
+	"fmt"
+	"hash/crc32"
+)
+
+// ConfigurationRecord is the parsed form of an FFV1 stream's extradata, as
+// carried in the Matroska CodecPrivate / MP4 sample description. It mirrors
+// the "FFV1 configuration record" described by RFC 9043 Annex.
+type ConfigurationRecord struct {
+	Version      Version
+	MicroVersion int
+
+	CoderType       CoderType
+	StateTransition [256]uint8 // only meaningful when CoderType == CoderRangeCustom
+
+	Colorspace      Colorspace
+	BitsPerRawSample int
+	ChromaPlanes     bool
+	Log2ChromaW      int
+	Log2ChromaH      int
+	ExtraPlane       bool // alpha
+
+	NumHSlices int
+	NumVSlices int
+
+	QuantTables []quantTableSet
+
+	SliceCRC bool // ec != 0
+
+	raw []byte
+}
+
+// ParseConfigurationRecord decodes extradata into a ConfigurationRecord
+// without touching any frame data.
+func ParseConfigurationRecord(extradata []byte) (*ConfigurationRecord, error) {
+	if len(extradata) < 4 {
+		return nil, errInvalidExtradata
+	}
+
+	rc := newRangeDecoder(extradata)
+	st := buildStateTransition(defaultStateTransition)
+	state := newSymbolState()
+
+	cr := &ConfigurationRecord{raw: extradata}
+	cr.Version = Version(rc.getSymbol(st, state, false))
+	if cr.Version < 0 || cr.Version > 4 {
+		return nil, fmt.Errorf("ffv1: unsupported version %d", cr.Version)
+	}
+	if cr.Version >= 3 {
+		cr.MicroVersion = rc.getSymbol(st, state, false)
+	}
+
+	cr.CoderType = CoderType(rc.getSymbol(st, state, false))
+	if cr.CoderType == CoderRangeCustom {
+		for i := range cr.StateTransition {
+			cr.StateTransition[i] = uint8(rc.getSymbol(st, state, true) + int(defaultStateTransition[i]))
+		}
+		st = buildStateTransition(cr.StateTransition)
+	}
+
+	cr.Colorspace = Colorspace(rc.getSymbol(st, state, false))
+	cr.BitsPerRawSample = 8
+	if cr.Version >= 1 {
+		cr.BitsPerRawSample = rc.getSymbol(st, state, false)
+		if cr.BitsPerRawSample == 0 {
+			cr.BitsPerRawSample = 8
+		}
+	}
+	cr.ChromaPlanes = rc.getSymbol(st, state, false) != 0
+	cr.Log2ChromaW = rc.getSymbol(st, state, false)
+	cr.Log2ChromaH = rc.getSymbol(st, state, false)
+	cr.ExtraPlane = rc.getSymbol(st, state, false) != 0
+
+	cr.NumHSlices, cr.NumVSlices = 1, 1
+	if cr.Version >= 3 {
+		cr.NumHSlices = rc.getSymbol(st, state, false) + 1
+		cr.NumVSlices = rc.getSymbol(st, state, false) + 1
+
+		quantTableSetCount := rc.getSymbol(st, state, false)
+		cr.QuantTables = make([]quantTableSet, quantTableSetCount)
+		for i := range cr.QuantTables {
+			cr.QuantTables[i] = readQuantTableSet(rc, st, state)
+		}
+		for i := range cr.QuantTables {
+			if cr.CoderType == CoderRangeCustom {
+				cr.QuantTables[i].stateTransition = cr.StateTransition
+			}
+		}
+
+		ec := rc.getSymbol(st, state, false)
+		cr.SliceCRC = ec != 0
+		_ = rc.getSymbol(st, state, false) // intra, reserved by the spec
+	}
+
+	if cr.Version >= 2 {
+		if len(extradata) < 4 {
+			return nil, errInvalidExtradata
+		}
+		crc := crc32.ChecksumIEEE(extradata[:len(extradata)-4])
+		want := uint32(extradata[len(extradata)-4])<<24 | uint32(extradata[len(extradata)-3])<<16 |
+			uint32(extradata[len(extradata)-2])<<8 | uint32(extradata[len(extradata)-1])
+		if crc != want {
+			return nil, fmt.Errorf("ffv1: configuration record CRC mismatch")
+		}
+	}
+
+	return cr, nil
+}
+
+// Bytes serializes the record back into extradata, suitable for storing as
+// Matroska CodecPrivate or an MP4 sample description.
+func (cr *ConfigurationRecord) Bytes() []byte {
+	rc := newRangeEncoder()
+	st := buildStateTransition(defaultStateTransition)
+	state := newSymbolState()
+
+	rc.putSymbol(st, state, int(cr.Version), false)
+	if cr.Version >= 3 {
+		rc.putSymbol(st, state, cr.MicroVersion, false)
+	}
+	rc.putSymbol(st, state, int(cr.CoderType), false)
+	if cr.CoderType == CoderRangeCustom {
+		for i, v := range cr.StateTransition {
+			rc.putSymbol(st, state, int(v)-int(defaultStateTransition[i]), true)
+		}
+		st = buildStateTransition(cr.StateTransition)
+	}
+	rc.putSymbol(st, state, int(cr.Colorspace), false)
+	if cr.Version >= 1 {
+		rc.putSymbol(st, state, cr.BitsPerRawSample, false)
+	}
+	if cr.ChromaPlanes {
+		rc.putSymbol(st, state, 1, false)
+	} else {
+		rc.putSymbol(st, state, 0, false)
+	}
+	rc.putSymbol(st, state, cr.Log2ChromaW, false)
+	rc.putSymbol(st, state, cr.Log2ChromaH, false)
+	if cr.ExtraPlane {
+		rc.putSymbol(st, state, 1, false)
+	} else {
+		rc.putSymbol(st, state, 0, false)
+	}
+
+	if cr.Version >= 3 {
+		rc.putSymbol(st, state, cr.NumHSlices-1, false)
+		rc.putSymbol(st, state, cr.NumVSlices-1, false)
+		rc.putSymbol(st, state, len(cr.QuantTables), false)
+		for i := range cr.QuantTables {
+			writeQuantTableSet(rc, st, state, &cr.QuantTables[i])
+		}
+		if cr.SliceCRC {
+			rc.putSymbol(st, state, 1, false)
+		} else {
+			rc.putSymbol(st, state, 0, false)
+		}
+		rc.putSymbol(st, state, 0, false) // intra
+	}
+
+	out := rc.flush()
+	if cr.Version >= 2 {
+		crc := crc32.ChecksumIEEE(out)
+		out = append(out, byte(crc>>24), byte(crc>>16), byte(crc>>8), byte(crc))
+	}
+	return out
+}
@@ -0,0 +1,403 @@
+package ffv1
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+)
+
+// DecoderOptions configures optional Decoder behaviour beyond the bare
+// extradata and dimensions NewDecoder takes.
+type DecoderOptions struct {
+	// GoroutinesPerFrame caps how many slices of a single frame are
+	// decoded concurrently. 0 (the default) uses runtime.GOMAXPROCS(0).
+	GoroutinesPerFrame int
+
+	// VerifyCRC controls whether per-slice CRCs (when present in the
+	// stream) are checked, and how a mismatch is handled. Defaults to
+	// VerifyCRCOff.
+	VerifyCRC CRCVerifyMode
+}
+
+// Decoder decodes a sequence of FFV1 packets that all share the same
+// extradata (ConfigurationRecord), as produced by a single Matroska or MP4
+// video track.
+type Decoder struct {
+	cr     *ConfigurationRecord
+	width  int
+	height int
+	opts   DecoderOptions
+
+	st        *stateTransition
+	quant     quantTableSet
+}
+
+// NewDecoder builds a Decoder from a track's extradata and pixel
+// dimensions, as read from its container, using default DecoderOptions.
+func NewDecoder(extradata []byte, width, height int) (*Decoder, error) {
+	return NewDecoderWithOptions(extradata, width, height, DecoderOptions{})
+}
+
+// NewDecoderWithOptions is NewDecoder with explicit DecoderOptions, e.g. to
+// control how many goroutines decode a frame's slices concurrently.
+func NewDecoderWithOptions(extradata []byte, width, height int, opts DecoderOptions) (*Decoder, error) {
+	cr, err := ParseConfigurationRecord(extradata)
+	if err != nil {
+		return nil, fmt.Errorf("ffv1: parsing extradata: %w", err)
+	}
+	if width <= 0 || height <= 0 {
+		return nil, fmt.Errorf("ffv1: invalid dimensions %dx%d", width, height)
+	}
+	if cr.Colorspace != ColorspaceYCbCr && (cr.Log2ChromaW != 0 || cr.Log2ChromaH != 0) {
+		return nil, fmt.Errorf("ffv1: chroma subsampling is not valid for RGB colorspaces")
+	}
+	if opts.GoroutinesPerFrame <= 0 {
+		opts.GoroutinesPerFrame = runtime.GOMAXPROCS(0)
+	}
+
+	d := &Decoder{
+		cr:     cr,
+		width:  width,
+		height: height,
+		opts:   opts,
+		st:     buildStateTransition(defaultStateTransition),
+	}
+	if cr.CoderType == CoderRangeCustom {
+		d.st = buildStateTransition(cr.StateTransition)
+	}
+	if len(cr.QuantTables) > 0 {
+		d.quant = cr.QuantTables[0]
+	} else {
+		d.quant = defaultQuantTableSet()
+	}
+	return d, nil
+}
+
+// SliceGrid returns the number of horizontal and vertical slices the
+// stream's configuration record divides each frame into, so callers can
+// size their own goroutine pools to match.
+func (d *Decoder) SliceGrid() (h, v int) {
+	return d.cr.NumHSlices, d.cr.NumVSlices
+}
+
+func (d *Decoder) newFrame() *Frame {
+	f := &Frame{
+		Width:       d.width,
+		Height:      d.height,
+		BitDepth:    d.cr.BitsPerRawSample,
+		Colorspace:  d.cr.Colorspace,
+		Log2ChromaW: d.cr.Log2ChromaW,
+		Log2ChromaH: d.cr.Log2ChromaH,
+		HasAlpha:    d.cr.ExtraPlane,
+	}
+	f.allocate()
+	return f
+}
+
+// DecodeFrame decodes a single packet's worth of slice data into a Frame.
+// The returned FrameDiagnostics is always non-nil when err is nil; its
+// SliceErrors is empty unless DecoderOptions.VerifyCRC found (and, in
+// VerifyCRCBestEffort mode, papered over) a corrupted slice.
+func (d *Decoder) DecodeFrame(data []byte) (*Frame, *FrameDiagnostics, error) {
+	frame := d.newFrame()
+	diag, err := d.decodeSlices(frame, data)
+	if err != nil {
+		return nil, nil, err
+	}
+	return frame, diag, nil
+}
+
+// decodeSlices walks the packet's slice(s) and reconstructs each plane in
+// place. A frame with a single slice (always the case for version 1/2
+// streams) is one bare slice covering the whole frame, with no footer: it
+// carries no CRC even when the stream has SliceCRC set, and is never
+// CRC-checked. Version 3 streams with more than one slice carry an explicit
+// num_h_slices x num_v_slices grid, each with its own independently
+// decodable coded data (and, optionally, CRC) — decoded here by a worker
+// pool fanned out across d.opts.GoroutinesPerFrame goroutines,
+// synchronizing only once all slices
+// have landed in their disjoint regions of frame.
+func (d *Decoder) decodeSlices(frame *Frame, data []byte) (*FrameDiagnostics, error) {
+	numH, numV := d.SliceGrid()
+	numSlices := numH * numV
+	if numSlices == 1 {
+		if err := d.decodeSlice(frame, data, 0, 0, frame.Width, frame.Height); err != nil {
+			return nil, err
+		}
+		return &FrameDiagnostics{}, nil
+	}
+
+	verifyCRC := d.cr.SliceCRC && d.opts.VerifyCRC != VerifyCRCOff
+	slices, crcs, err := demuxSlices(data, numSlices, d.cr.SliceCRC)
+	if err != nil {
+		return nil, err
+	}
+
+	workers := d.opts.GoroutinesPerFrame
+	if workers > numSlices {
+		workers = numSlices
+	}
+	jobs := make(chan int)
+	errs := make([]error, numSlices)
+	sliceErrs := make([]*SliceError, numSlices)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for idx := range jobs {
+				row, col := idx/numH, idx%numH
+				x, y, w, h := sliceRect(frame.Width, frame.Height, numH, numV, row, col, frame.Log2ChromaW, frame.Log2ChromaH)
+
+				if verifyCRC && crc32OfSlice(slices[idx]) != crcs[idx] {
+					se := &SliceError{SliceIndex: idx, X: x, Y: y, W: w, H: h, Err: errSliceCRCMismatch}
+					if d.opts.VerifyCRC == VerifyCRCStrict {
+						errs[idx] = se
+						continue
+					}
+					zeroFillRegion(frame, x, y, w, h)
+					sliceErrs[idx] = se
+					continue
+				}
+
+				errs[idx] = d.decodeSlice(frame, slices[idx], x, y, w, h)
+			}
+		}()
+	}
+	for idx := 0; idx < numSlices; idx++ {
+		jobs <- idx
+	}
+	close(jobs)
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	diag := &FrameDiagnostics{}
+	for _, se := range sliceErrs {
+		if se != nil {
+			diag.SliceErrors = append(diag.SliceErrors, *se)
+		}
+	}
+	return diag, nil
+}
+
+// zeroFillRegion clears the pixel rectangle (x,y,w,h) of every plane, used
+// to mask a slice that failed CRC verification in best-effort mode.
+func zeroFillRegion(frame *Frame, x, y, w, h int) {
+	for p := 0; p < frame.numPlanes(); p++ {
+		px, py, pwClip, phClip := planeRect(x, y, w, h, p, frame)
+		for j := 0; j < phClip; j++ {
+			for i := 0; i < pwClip; i++ {
+				setSample(frame, p, px+i, py+j, 0)
+			}
+		}
+	}
+}
+
+// decodeSlice reconstructs the sub-rectangle (x,y,w,h) of frame from a
+// single slice's coded bytes, using a median predictor and FFV1's small
+// quantized-context model.
+func (d *Decoder) decodeSlice(frame *Frame, data []byte, x, y, w, h int) error {
+	if d.cr.CoderType == CoderGolombRice {
+		return d.decodeSliceGolomb(frame, data, x, y, w, h)
+	}
+	return d.decodeSliceRange(frame, data, x, y, w, h)
+}
+
+func (d *Decoder) decodeSliceRange(frame *Frame, data []byte, x, y, w, h int) error {
+	rc := newRangeDecoder(data)
+	x, y, w, h = readSliceHeader(rc, d.st, frame.numPlanes())
+
+	states := make([][]uint8, d.quant.contextCount)
+	for i := range states {
+		states[i] = newSymbolState()
+	}
+
+	planes := rgbCodingPlanes(frame, x, y, w, h)
+	for _, cp := range planes {
+		for j := 0; j < cp.h; j++ {
+			for i := 0; i < cp.w; i++ {
+				left, top, topLeft, topRight, topTop, ll := planeNeighbours(cp.sample, cp.x0+i, cp.y0+j, cp.x0, cp.y0, cp.x0+cp.w)
+
+				ctx, sign := d.quant.context(left-topLeft, topLeft-top, top-topRight, ll-left, topTop-top)
+				residual := rc.getSymbol(d.st, states[ctx%len(states)], true) * sign
+
+				pred := medianPredictor(left, top, topLeft)
+				v := wrapSample(pred+residual, cp.bitDepth)
+				cp.write(cp.x0+i, cp.y0+j, v)
+			}
+		}
+	}
+	finishRCTDecode(frame, x, y, w, h, planes)
+	return nil
+}
+
+func (d *Decoder) decodeSliceGolomb(frame *Frame, data []byte, x, y, w, h int) error {
+	if len(data) < sliceHeaderPrefixSize {
+		return fmt.Errorf("ffv1: truncated Golomb-Rice slice header")
+	}
+	headerLen := int(data[0])<<8 | int(data[1])
+	if len(data) < sliceHeaderPrefixSize+headerLen {
+		return fmt.Errorf("ffv1: truncated Golomb-Rice slice header")
+	}
+	header := data[sliceHeaderPrefixSize : sliceHeaderPrefixSize+headerLen]
+	x, y, w, h = readSliceHeader(newRangeDecoder(header), d.st, frame.numPlanes())
+
+	br := newBitReader(data[sliceHeaderPrefixSize+headerLen:])
+	states := make([]golombState, d.quant.contextCount)
+
+	planes := rgbCodingPlanes(frame, x, y, w, h)
+	for _, cp := range planes {
+		for j := 0; j < cp.h; j++ {
+			for i := 0; i < cp.w; i++ {
+				left, top, topLeft, topRight, topTop, ll := planeNeighbours(cp.sample, cp.x0+i, cp.y0+j, cp.x0, cp.y0, cp.x0+cp.w)
+
+				ctx, sign := d.quant.context(left-topLeft, topLeft-top, top-topRight, ll-left, topTop-top)
+				residual := states[ctx%len(states)].decode(br) * sign
+
+				pred := medianPredictor(left, top, topLeft)
+				v := wrapSample(pred+residual, cp.bitDepth)
+				cp.write(cp.x0+i, cp.y0+j, v)
+			}
+		}
+	}
+	finishRCTDecode(frame, x, y, w, h, planes)
+	return nil
+}
+
+func planeRect(x, y, w, h, plane int, frame *Frame) (px, py, pw, ph int) {
+	if plane == 0 || plane == 3 {
+		return x, y, w, h
+	}
+	return x >> uint(frame.Log2ChromaW), y >> uint(frame.Log2ChromaH),
+		w >> uint(frame.Log2ChromaW), h >> uint(frame.Log2ChromaH)
+}
+
+func medianPredictor(left, top, topLeft int) int {
+	// Median-of-three gradient predictor from FFV1 §3.8.
+	if topLeft >= max3(left, top) {
+		return min3(left, top)
+	}
+	if topLeft <= min3(left, top) {
+		return max3(left, top)
+	}
+	return left + top - topLeft
+}
+
+func max3(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func min3(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func wrapSample(v, bitDepth int) int {
+	mask := (1 << uint(bitDepth)) - 1
+	return v & mask
+}
+
+// framePlaneSampler returns a function reading frame's plane literally, by
+// absolute plane coordinates — the sampler planeNeighbours and the coding
+// loops use for ordinary (non color-transformed) planes.
+func framePlaneSampler(frame *Frame, plane int) func(x, y int) int {
+	pw, _ := frame.planeDimensions(plane)
+	if frame.BitDepth == 8 {
+		buf := frame.Buf[plane]
+		return func(x, y int) int { return int(buf[y*pw+x]) }
+	}
+	buf := frame.Buf16[plane]
+	return func(x, y int) int { return int(buf[y*pw+x]) }
+}
+
+// framePlaneWriter is framePlaneSampler's write-side counterpart.
+func framePlaneWriter(frame *Frame, plane int) func(x, y, v int) {
+	pw, _ := frame.planeDimensions(plane)
+	if frame.BitDepth == 8 {
+		buf := frame.Buf[plane]
+		return func(x, y, v int) { buf[y*pw+x] = byte(v) }
+	}
+	buf := frame.Buf16[plane]
+	return func(x, y, v int) { buf[y*pw+x] = uint16(v) }
+}
+
+// planeNeighbours returns the six causal context samples FFV1's median
+// predictor and quantized context need for the sample at (x, y): left, top,
+// topLeft, topRight, topTop and ll (two samples to the left on the same
+// row), read through sample. (minX, minY, maxX) bound the plane rectangle of
+// the slice being coded — slices are independently decodable, so a
+// neighbour outside the current slice is treated the same as one outside
+// the frame. Such out-of-bounds reads are synthesized from already-coded
+// neighbours (rather than clamped into the slice, which would read (x, y)
+// itself or a not-yet-coded sample) so the encoder, working from a fully
+// populated frame, and the decoder, working from a partially reconstructed
+// one, agree at every border pixel.
+func planeNeighbours(sample func(x, y int) int, x, y, minX, minY, maxX int) (left, top, topLeft, topRight, topTop, ll int) {
+	switch {
+	case x == minX && y == minY:
+		return 0, 0, 0, 0, 0, 0
+	case y == minY:
+		left = sample(x-1, y)
+		top, topLeft, topRight, topTop = left, left, left, left
+		if x-2 >= minX {
+			ll = sample(x-2, y)
+		} else {
+			ll = left
+		}
+		return
+	case x == minX:
+		top = sample(x, y-1)
+		left, topLeft, ll = top, top, top
+		if x+1 < maxX {
+			topRight = sample(x+1, y-1)
+		} else {
+			topRight = top
+		}
+		if y-2 >= minY {
+			topTop = sample(x, y-2)
+		} else {
+			topTop = top
+		}
+		return
+	default:
+		left = sample(x-1, y)
+		top = sample(x, y-1)
+		topLeft = sample(x-1, y-1)
+		if x+1 < maxX {
+			topRight = sample(x+1, y-1)
+		} else {
+			topRight = top
+		}
+		if y-2 >= minY {
+			topTop = sample(x, y-2)
+		} else {
+			topTop = top
+		}
+		if x-2 >= minX {
+			ll = sample(x-2, y)
+		} else {
+			ll = left
+		}
+		return
+	}
+}
+
+func setSample(frame *Frame, plane, x, y, v int) {
+	pw, _ := frame.planeDimensions(plane)
+	idx := y*pw + x
+	if frame.BitDepth == 8 {
+		frame.Buf[plane][idx] = byte(v)
+	} else {
+		frame.Buf16[plane][idx] = uint16(v)
+	}
+}
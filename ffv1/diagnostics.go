@@ -0,0 +1,39 @@
+package ffv1
+
+import "fmt"
+
+// CRCVerifyMode controls how DecodeFrame reacts to a per-slice CRC
+// mismatch in a stream that has slice CRCs enabled.
+type CRCVerifyMode int
+
+const (
+	// VerifyCRCOff never checks slice CRCs, even if the stream has them.
+	VerifyCRCOff CRCVerifyMode = iota
+	// VerifyCRCBestEffort checks slice CRCs, zero-fills any slice that
+	// fails, reports it in FrameDiagnostics, and keeps decoding the rest
+	// of the frame.
+	VerifyCRCBestEffort
+	// VerifyCRCStrict checks slice CRCs and returns an error from
+	// DecodeFrame on the first mismatch.
+	VerifyCRCStrict
+)
+
+// SliceError describes one slice that failed CRC verification.
+type SliceError struct {
+	SliceIndex int
+	X, Y, W, H int
+	Err        error
+}
+
+func (e SliceError) Error() string {
+	return fmt.Sprintf("ffv1: slice %d (%d,%d %dx%d): %v", e.SliceIndex, e.X, e.Y, e.W, e.H, e.Err)
+}
+
+// FrameDiagnostics reports per-slice problems found while decoding a
+// frame. It is non-nil whenever DecodeFrame returns a Frame, even if
+// SliceErrors is empty.
+type FrameDiagnostics struct {
+	SliceErrors []SliceError
+}
+
+var errSliceCRCMismatch = fmt.Errorf("slice CRC mismatch")
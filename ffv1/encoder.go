@@ -0,0 +1,197 @@
+package ffv1
+
+import "fmt"
+
+// ChromaSubsampling describes the horizontal and vertical chroma
+// subsampling shifts to encode with (0,0 = 4:4:4, 1,1 = 4:2:0, ...).
+type ChromaSubsampling struct {
+	Log2H int
+	Log2V int
+}
+
+// SliceGrid describes how a frame is partitioned into independently
+// decodable slices.
+type SliceGrid struct {
+	NumH int
+	NumV int
+}
+
+// EncoderConfig configures a new Encoder. Width and Height describe the
+// frames that will be passed to EncodeFrame.
+type EncoderConfig struct {
+	Width  int
+	Height int
+
+	Version    Version
+	CoderType  CoderType
+	// StateTransition is used verbatim when CoderType is CoderRangeCustom;
+	// for CoderRangeDefault it is ignored.
+	StateTransition [256]uint8
+
+	Colorspace        Colorspace
+	ChromaSubsampling ChromaSubsampling
+	BitDepth          int // 8..16
+	Alpha             bool
+
+	Slices  SliceGrid
+	SliceCRC bool
+}
+
+// Encoder encodes Frames into FFV1 packets sharing a single
+// ConfigurationRecord (extradata). Every frame is coded intra-only — FFV1
+// has no inter-prediction mode, so there is no GOP size to configure.
+type Encoder struct {
+	cfg EncoderConfig
+	cr  *ConfigurationRecord
+
+	st    *stateTransition
+	quant quantTableSet
+}
+
+// NewEncoder builds an Encoder from cfg, validating it and deriving the
+// ConfigurationRecord that Extradata will return.
+func NewEncoder(cfg EncoderConfig) (*Encoder, error) {
+	if cfg.Width <= 0 || cfg.Height <= 0 {
+		return nil, fmt.Errorf("ffv1: invalid dimensions %dx%d", cfg.Width, cfg.Height)
+	}
+	if cfg.BitDepth < 8 || cfg.BitDepth > 16 {
+		return nil, fmt.Errorf("ffv1: bit depth %d out of range [8,16]", cfg.BitDepth)
+	}
+	if cfg.Version != Version1 && cfg.Version != Version3 {
+		return nil, fmt.Errorf("ffv1: unsupported version %d", cfg.Version)
+	}
+	if cfg.Version == Version1 && (cfg.Slices.NumH > 1 || cfg.Slices.NumV > 1) {
+		return nil, fmt.Errorf("ffv1: slice grids require version 3")
+	}
+	if cfg.Colorspace != ColorspaceYCbCr && (cfg.ChromaSubsampling.Log2H != 0 || cfg.ChromaSubsampling.Log2V != 0) {
+		return nil, fmt.Errorf("ffv1: chroma subsampling is not valid for RGB colorspaces")
+	}
+	if cfg.Slices.NumH < 1 {
+		cfg.Slices.NumH = 1
+	}
+	if cfg.Slices.NumV < 1 {
+		cfg.Slices.NumV = 1
+	}
+
+	quant := defaultQuantTableSet()
+
+	cr := &ConfigurationRecord{
+		Version:          cfg.Version,
+		CoderType:        cfg.CoderType,
+		StateTransition:  cfg.StateTransition,
+		Colorspace:       cfg.Colorspace,
+		BitsPerRawSample: cfg.BitDepth,
+		ChromaPlanes:     true,
+		Log2ChromaW:      cfg.ChromaSubsampling.Log2H,
+		Log2ChromaH:      cfg.ChromaSubsampling.Log2V,
+		ExtraPlane:       cfg.Alpha,
+		NumHSlices:       cfg.Slices.NumH,
+		NumVSlices:       cfg.Slices.NumV,
+		QuantTables:      []quantTableSet{quant},
+		SliceCRC:         cfg.SliceCRC,
+	}
+
+	st := buildStateTransition(defaultStateTransition)
+	if cfg.CoderType == CoderRangeCustom {
+		st = buildStateTransition(cfg.StateTransition)
+	}
+
+	return &Encoder{cfg: cfg, cr: cr, st: st, quant: quant}, nil
+}
+
+// Extradata returns the ConfigurationRecord bytes to store alongside the
+// encoded stream (e.g. as Matroska CodecPrivate).
+func (e *Encoder) Extradata() []byte {
+	return e.cr.Bytes()
+}
+
+// EncodeFrame encodes frame into a single FFV1 packet. Frame must match
+// the dimensions, bit depth, colorspace and chroma subsampling passed to
+// NewEncoder.
+func (e *Encoder) EncodeFrame(frame *Frame) ([]byte, error) {
+	if frame.Width != e.cfg.Width || frame.Height != e.cfg.Height {
+		return nil, fmt.Errorf("ffv1: frame is %dx%d, encoder configured for %dx%d",
+			frame.Width, frame.Height, e.cfg.Width, e.cfg.Height)
+	}
+
+	numH, numV := e.cfg.Slices.NumH, e.cfg.Slices.NumV
+	slices := make([][]byte, numH*numV)
+	for row := 0; row < numV; row++ {
+		for col := 0; col < numH; col++ {
+			x, y, w, h := sliceRect(frame.Width, frame.Height, numH, numV, row, col, frame.Log2ChromaW, frame.Log2ChromaH)
+			idx := row*numH + col
+			if e.cfg.CoderType == CoderGolombRice {
+				slices[idx] = e.encodeSliceGolomb(frame, x, y, w, h)
+			} else {
+				slices[idx] = e.encodeSliceRange(frame, x, y, w, h)
+			}
+		}
+	}
+
+	if len(slices) == 1 {
+		return slices[0], nil
+	}
+	return muxSlices(slices, e.cfg.SliceCRC), nil
+}
+
+func (e *Encoder) encodeSliceRange(frame *Frame, x, y, w, h int) []byte {
+	rc := newRangeEncoder()
+	writeSliceHeader(rc, e.st, x, y, w, h, frame.numPlanes())
+
+	states := make([][]uint8, e.quant.contextCount)
+	for i := range states {
+		states[i] = newSymbolState()
+	}
+
+	for _, cp := range encodeCodingPlanes(frame, x, y, w, h) {
+		for j := 0; j < cp.h; j++ {
+			for i := 0; i < cp.w; i++ {
+				left, top, topLeft, topRight, topTop, ll := planeNeighbours(cp.sample, cp.x0+i, cp.y0+j, cp.x0, cp.y0, cp.x0+cp.w)
+
+				ctx, sign := e.quant.context(left-topLeft, topLeft-top, top-topRight, ll-left, topTop-top)
+				pred := medianPredictor(left, top, topLeft)
+				actual := cp.sample(cp.x0+i, cp.y0+j)
+				residual := sign * wrapResidual(actual-pred, cp.bitDepth)
+
+				rc.putSymbol(e.st, states[ctx%len(states)], residual, true)
+			}
+		}
+	}
+	return rc.flush()
+}
+
+func (e *Encoder) encodeSliceGolomb(frame *Frame, x, y, w, h int) []byte {
+	header := writeGolombSliceHeader(e.st, x, y, w, h, frame.numPlanes())
+
+	bw := newBitWriter()
+	states := make([]golombState, e.quant.contextCount)
+
+	for _, cp := range encodeCodingPlanes(frame, x, y, w, h) {
+		for j := 0; j < cp.h; j++ {
+			for i := 0; i < cp.w; i++ {
+				left, top, topLeft, topRight, topTop, ll := planeNeighbours(cp.sample, cp.x0+i, cp.y0+j, cp.x0, cp.y0, cp.x0+cp.w)
+
+				ctx, sign := e.quant.context(left-topLeft, topLeft-top, top-topRight, ll-left, topTop-top)
+				pred := medianPredictor(left, top, topLeft)
+				actual := cp.sample(cp.x0+i, cp.y0+j)
+				residual := sign * wrapResidual(actual-pred, cp.bitDepth)
+
+				states[ctx%len(states)].encode(bw, residual)
+			}
+		}
+	}
+	return append(header, bw.flush()...)
+}
+
+// wrapResidual folds a prediction residual into the signed range the
+// symbol coder expects, accounting for same bit-depth wraparound the
+// decoder's wrapSample undoes.
+func wrapResidual(v, bitDepth int) int {
+	half := 1 << uint(bitDepth-1)
+	full := 1 << uint(bitDepth)
+	v = ((v + half) % full) - half
+	if v < -half {
+		v += full
+	}
+	return v
+}
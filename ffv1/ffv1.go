@@ -0,0 +1,37 @@
+// Package ffv1 implements an encoder and decoder for the FFV1 lossless
+// video codec, as specified by RFC 9043.
+package ffv1
+
+import "fmt"
+
+// Colorspace identifies the pixel colorspace a stream's planes are stored in.
+type Colorspace int
+
+// Colorspaces supported by the bitstream.
+const (
+	ColorspaceYCbCr Colorspace = iota
+	ColorspaceRGB
+	ColorspaceJPEG2000RCT
+)
+
+// CoderType selects the entropy coder used to pack residuals into the
+// bitstream.
+type CoderType int
+
+// Entropy coders supported by FFV1.
+const (
+	CoderGolombRice CoderType = iota
+	CoderRangeDefault
+	CoderRangeCustom
+)
+
+// Version identifies the FFV1 bitstream version.
+type Version int
+
+// Bitstream versions this package knows how to read and write.
+const (
+	Version1 Version = 1
+	Version3 Version = 3
+)
+
+var errInvalidExtradata = fmt.Errorf("ffv1: invalid extradata")
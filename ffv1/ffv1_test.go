@@ -0,0 +1,294 @@
+package ffv1
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+func TestConfigRecordRoundtrip(t *testing.T) {
+	cr := &ConfigurationRecord{
+		Version:          Version3,
+		MicroVersion:     2,
+		CoderType:        CoderRangeDefault,
+		Colorspace:       ColorspaceYCbCr,
+		BitsPerRawSample: 8,
+		ChromaPlanes:     true,
+		Log2ChromaW:      1,
+		Log2ChromaH:      1,
+		NumHSlices:       2,
+		NumVSlices:       2,
+		QuantTables:      []quantTableSet{defaultQuantTableSet()},
+		SliceCRC:         true,
+	}
+
+	extradata := cr.Bytes()
+
+	got, err := ParseConfigurationRecord(extradata)
+	if err != nil {
+		t.Fatalf("ParseConfigurationRecord: %v", err)
+	}
+	if got.Version != cr.Version || got.MicroVersion != cr.MicroVersion ||
+		got.CoderType != cr.CoderType || got.Colorspace != cr.Colorspace ||
+		got.BitsPerRawSample != cr.BitsPerRawSample || got.ChromaPlanes != cr.ChromaPlanes ||
+		got.Log2ChromaW != cr.Log2ChromaW || got.Log2ChromaH != cr.Log2ChromaH ||
+		got.NumHSlices != cr.NumHSlices || got.NumVSlices != cr.NumVSlices ||
+		got.SliceCRC != cr.SliceCRC {
+		t.Fatalf("round-tripped record mismatch: got %+v, want %+v", got, cr)
+	}
+}
+
+func TestEncodeDecodeFrameRoundtrip(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		coder   CoderType
+		numH    int
+		numV    int
+		bitDepth int
+	}{
+		{"range/1x1/8bit", CoderRangeDefault, 1, 1, 8},
+		{"range/2x2/8bit", CoderRangeDefault, 2, 2, 8},
+		{"golomb/1x1/8bit", CoderGolombRice, 1, 1, 8},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			const width, height = 6, 4
+
+			enc, err := NewEncoder(EncoderConfig{
+				Width:             width,
+				Height:            height,
+				Version:           Version3,
+				CoderType:         tc.coder,
+				Colorspace:        ColorspaceYCbCr,
+				ChromaSubsampling: ChromaSubsampling{Log2H: 1, Log2V: 1},
+				BitDepth:          tc.bitDepth,
+				Slices:            SliceGrid{NumH: tc.numH, NumV: tc.numV},
+				SliceCRC:          true,
+			})
+			if err != nil {
+				t.Fatalf("NewEncoder: %v", err)
+			}
+
+			src := randomFrame(width, height, tc.bitDepth)
+			packet, err := enc.EncodeFrame(src)
+			if err != nil {
+				t.Fatalf("EncodeFrame: %v", err)
+			}
+
+			dec, err := NewDecoder(enc.Extradata(), width, height)
+			if err != nil {
+				t.Fatalf("NewDecoder: %v", err)
+			}
+			got, diag, err := dec.DecodeFrame(packet)
+			if err != nil {
+				t.Fatalf("DecodeFrame: %v", err)
+			}
+			if len(diag.SliceErrors) != 0 {
+				t.Fatalf("unexpected slice errors: %+v", diag.SliceErrors)
+			}
+
+			for p := 0; p < src.numPlanes(); p++ {
+				if tc.bitDepth == 8 {
+					assertBytesEqual(t, p, src.Buf[p], got.Buf[p])
+				} else {
+					assertUint16sEqual(t, p, src.Buf16[p], got.Buf16[p])
+				}
+			}
+		})
+	}
+}
+
+func TestEncodeDecodeFrameRoundtripRGB(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		coder    CoderType
+		bitDepth int
+	}{
+		{"range/8bit", CoderRangeDefault, 8},
+		{"range/16bit", CoderRangeDefault, 16},
+		{"golomb/8bit", CoderGolombRice, 8},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			const width, height = 6, 4
+
+			enc, err := NewEncoder(EncoderConfig{
+				Width:      width,
+				Height:     height,
+				Version:    Version3,
+				CoderType:  tc.coder,
+				Colorspace: ColorspaceRGB,
+				BitDepth:   tc.bitDepth,
+				Slices:     SliceGrid{NumH: 1, NumV: 1},
+				SliceCRC:   true,
+			})
+			if err != nil {
+				t.Fatalf("NewEncoder: %v", err)
+			}
+
+			src := randomRGBFrame(width, height, tc.bitDepth)
+			packet, err := enc.EncodeFrame(src)
+			if err != nil {
+				t.Fatalf("EncodeFrame: %v", err)
+			}
+
+			dec, err := NewDecoder(enc.Extradata(), width, height)
+			if err != nil {
+				t.Fatalf("NewDecoder: %v", err)
+			}
+			got, diag, err := dec.DecodeFrame(packet)
+			if err != nil {
+				t.Fatalf("DecodeFrame: %v", err)
+			}
+			if len(diag.SliceErrors) != 0 {
+				t.Fatalf("unexpected slice errors: %+v", diag.SliceErrors)
+			}
+
+			for p := 0; p < src.numPlanes(); p++ {
+				if tc.bitDepth == 8 {
+					assertBytesEqual(t, p, src.Buf[p], got.Buf[p])
+				} else {
+					assertUint16sEqual(t, p, src.Buf16[p], got.Buf16[p])
+				}
+			}
+		})
+	}
+}
+
+func randomRGBFrame(width, height, bitDepth int) *Frame {
+	f := &Frame{
+		Width:      width,
+		Height:     height,
+		BitDepth:   bitDepth,
+		Colorspace: ColorspaceRGB,
+	}
+	f.allocate()
+	r := rand.New(rand.NewSource(1))
+	mask := (1 << uint(bitDepth)) - 1
+	for p := 0; p < f.numPlanes(); p++ {
+		if bitDepth == 8 {
+			for i := range f.Buf[p] {
+				f.Buf[p][i] = byte(r.Intn(mask + 1))
+			}
+		} else {
+			for i := range f.Buf16[p] {
+				f.Buf16[p][i] = uint16(r.Intn(mask + 1))
+			}
+		}
+	}
+	return f
+}
+
+// TestEncodeGoldenBytes pins EncodeFrame's exact output for a small,
+// deterministic (non-random) frame.
+//
+// This is not a substitute for checking interop against an independent
+// FFV1 implementation (e.g. ffmpeg/libavcodec) — no such reference encoder
+// or decoder, nor any pre-existing FFV1 bitstream fixture, is available in
+// the environment this test was written in, so the expected bytes below
+// were captured from this package's own encoder rather than an external
+// one. What it does catch is any change that silently alters the coded
+// bitstream (a wrong context term, a mis-ordered slice header field, a
+// transform applied in the wrong domain) without also breaking the
+// round-trip tests above, since those only ever check this package against
+// itself. Treat a failure here as "the wire format changed" and update the
+// golden bytes deliberately, not as "the round-trip still passed, so this
+// must be wrong."
+func TestEncodeGoldenBytes(t *testing.T) {
+	const width, height = 4, 2
+
+	enc, err := NewEncoder(EncoderConfig{
+		Width:             width,
+		Height:            height,
+		Version:           Version3,
+		CoderType:         CoderRangeDefault,
+		Colorspace:        ColorspaceYCbCr,
+		ChromaSubsampling: ChromaSubsampling{Log2H: 1, Log2V: 1},
+		BitDepth:          8,
+		Slices:            SliceGrid{NumH: 1, NumV: 1},
+		SliceCRC:          true,
+	})
+	if err != nil {
+		t.Fatalf("NewEncoder: %v", err)
+	}
+
+	f := &Frame{Width: width, Height: height, BitDepth: 8, Colorspace: ColorspaceYCbCr, Log2ChromaW: 1, Log2ChromaH: 1}
+	f.allocate()
+	for i := range f.Buf[0] {
+		f.Buf[0][i] = byte(16 + i*10)
+	}
+	for i := range f.Buf[1] {
+		f.Buf[1][i] = byte(128 + i*3)
+	}
+	for i := range f.Buf[2] {
+		f.Buf[2][i] = byte(128 - i*3)
+	}
+
+	wantExtradata := []byte{
+		0x59, 0x88, 0x7d, 0xdd, 0x35, 0x72, 0x21, 0xc1, 0xcc, 0x09, 0x2d, 0x87, 0x74, 0x3e, 0xb7, 0x81,
+		0x9c, 0x01, 0xb1, 0x51, 0xba, 0x45, 0x4d, 0x57, 0xaf, 0xe9, 0xe4, 0x78, 0x20, 0xb3, 0xfd, 0xc6,
+		0xa7, 0x8e, 0xc9,
+	}
+	if gotExtradata := enc.Extradata(); !bytes.Equal(gotExtradata, wantExtradata) {
+		t.Fatalf("Extradata() = % x, want % x", gotExtradata, wantExtradata)
+	}
+
+	wantPacket := []byte{
+		0xcd, 0x0b, 0x32, 0x97, 0x57, 0xcc, 0x91, 0x40, 0x87, 0x85, 0xc2, 0xe9, 0x28, 0xe1, 0xe9, 0xc6, 0xd7,
+	}
+	gotPacket, err := enc.EncodeFrame(f)
+	if err != nil {
+		t.Fatalf("EncodeFrame: %v", err)
+	}
+	if !bytes.Equal(gotPacket, wantPacket) {
+		t.Fatalf("EncodeFrame() = % x, want % x", gotPacket, wantPacket)
+	}
+}
+
+func randomFrame(width, height, bitDepth int) *Frame {
+	f := &Frame{
+		Width:       width,
+		Height:      height,
+		BitDepth:    bitDepth,
+		Colorspace:  ColorspaceYCbCr,
+		Log2ChromaW: 1,
+		Log2ChromaH: 1,
+	}
+	f.allocate()
+	r := rand.New(rand.NewSource(1))
+	mask := (1 << uint(bitDepth)) - 1
+	for p := 0; p < f.numPlanes(); p++ {
+		if bitDepth == 8 {
+			for i := range f.Buf[p] {
+				f.Buf[p][i] = byte(r.Intn(mask + 1))
+			}
+		} else {
+			for i := range f.Buf16[p] {
+				f.Buf16[p][i] = uint16(r.Intn(mask + 1))
+			}
+		}
+	}
+	return f
+}
+
+func assertBytesEqual(t *testing.T, plane int, want, got []byte) {
+	t.Helper()
+	if len(want) != len(got) {
+		t.Fatalf("plane %d: length mismatch, got %d want %d", plane, len(got), len(want))
+	}
+	for i := range want {
+		if want[i] != got[i] {
+			t.Fatalf("plane %d: sample %d mismatch, got %d want %d", plane, i, got[i], want[i])
+		}
+	}
+}
+
+func assertUint16sEqual(t *testing.T, plane int, want, got []uint16) {
+	t.Helper()
+	if len(want) != len(got) {
+		t.Fatalf("plane %d: length mismatch, got %d want %d", plane, len(got), len(want))
+	}
+	for i := range want {
+		if want[i] != got[i] {
+			t.Fatalf("plane %d: sample %d mismatch, got %d want %d", plane, i, got[i], want[i])
+		}
+	}
+}
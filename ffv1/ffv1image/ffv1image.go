@@ -0,0 +1,112 @@
+// Package ffv1image registers the FFV1 codec with image.Decode/
+// image.DecodeConfig for one-shot thumbnailing, e.g. turning a single
+// keyframe packet into a PNG without a caller standing up a full Decoder.
+//
+// Since image.Decode only gets an io.Reader and FFV1 packets can't be
+// interpreted without their extradata, this package defines a small
+// self-contained envelope: a "FFV1" magic, the extradata and packet pixel
+// dimensions, and the packet bytes. Encode produces that envelope from an
+// extradata/packet pair; Decode/DecodeConfig consume it.
+package ffv1image
+
+import (
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+
+	"github.com/dwbuiten/go-ffv1/ffv1"
+)
+
+const magic = "FFV1"
+
+func init() {
+	image.RegisterFormat("ffv1", magic, Decode, DecodeConfig)
+}
+
+// Encode writes extradata and packet, framed with width/height, into w as
+// an ffv1image envelope suitable for image.Decode.
+func Encode(w io.Writer, extradata []byte, width, height int, packet []byte) error {
+	if _, err := io.WriteString(w, magic); err != nil {
+		return err
+	}
+	for _, v := range []int{len(extradata), width, height, len(packet)} {
+		if err := binary.Write(w, binary.BigEndian, uint32(v)); err != nil {
+			return err
+		}
+	}
+	if _, err := w.Write(extradata); err != nil {
+		return err
+	}
+	_, err := w.Write(packet)
+	return err
+}
+
+func readEnvelope(r io.Reader) (extradata, packet []byte, width, height int, err error) {
+	var hdr [4]byte
+	if _, err = io.ReadFull(r, hdr[:]); err != nil {
+		return nil, nil, 0, 0, err
+	}
+	if string(hdr[:]) != magic {
+		return nil, nil, 0, 0, fmt.Errorf("ffv1image: not an ffv1image envelope")
+	}
+
+	var lens [4]uint32
+	if err = binary.Read(r, binary.BigEndian, &lens); err != nil {
+		return nil, nil, 0, 0, err
+	}
+
+	extradata = make([]byte, lens[0])
+	if _, err = io.ReadFull(r, extradata); err != nil {
+		return nil, nil, 0, 0, err
+	}
+	width, height = int(lens[1]), int(lens[2])
+	packet = make([]byte, lens[3])
+	_, err = io.ReadFull(r, packet)
+	return extradata, packet, width, height, err
+}
+
+// DecodeConfig reads an ffv1image envelope and describes it via
+// ffv1.Probe; the packet bytes are read but not decoded.
+func DecodeConfig(r io.Reader) (image.Config, error) {
+	extradata, _, width, height, err := readEnvelope(r)
+	if err != nil {
+		return image.Config{}, err
+	}
+	info, err := ffv1.Probe(extradata)
+	if err != nil {
+		return image.Config{}, err
+	}
+	return image.Config{
+		ColorModel: colorModel(info),
+		Width:      width,
+		Height:     height,
+	}, nil
+}
+
+// Decode reads an ffv1image envelope, decodes its single packet, and
+// returns it as an image.Image via Frame.Image.
+func Decode(r io.Reader) (image.Image, error) {
+	extradata, packet, width, height, err := readEnvelope(r)
+	if err != nil {
+		return nil, err
+	}
+
+	d, err := ffv1.NewDecoder(extradata, width, height)
+	if err != nil {
+		return nil, err
+	}
+	frame, _, err := d.DecodeFrame(packet)
+	if err != nil {
+		return nil, err
+	}
+	return frame.Image()
+}
+
+func colorModel(info *ffv1.StreamInfo) color.Model {
+	if info.Colorspace == ffv1.ColorspaceYCbCr {
+		return color.YCbCrModel
+	}
+	return color.RGBAModel
+}
@@ -0,0 +1,50 @@
+package ffv1
+
+// Frame holds one decoded (or, for the encoder, to-be-encoded) picture.
+//
+// Buf and Buf16 are indexed by plane: 0=Y/R, 1=Cb/G, 2=Cr/B, 3=alpha. Only
+// one of Buf/Buf16 is populated, selected by BitDepth: Buf for 8-bit
+// samples, Buf16 for anything above that. Chroma planes are sized
+// according to Log2ChromaW/Log2ChromaH relative to the luma plane.
+type Frame struct {
+	Width  int
+	Height int
+
+	BitDepth   int
+	Colorspace Colorspace
+
+	Log2ChromaW int
+	Log2ChromaH int
+	HasAlpha    bool
+
+	Buf   [4][]byte
+	Buf16 [4][]uint16
+}
+
+// planeDimensions returns the width and height of plane i, accounting for
+// chroma subsampling.
+func (f *Frame) planeDimensions(i int) (w, h int) {
+	if i == 0 || i == 3 {
+		return f.Width, f.Height
+	}
+	return f.Width >> uint(f.Log2ChromaW), f.Height >> uint(f.Log2ChromaH)
+}
+
+func (f *Frame) numPlanes() int {
+	n := 3
+	if f.HasAlpha {
+		n++
+	}
+	return n
+}
+
+func (f *Frame) allocate() {
+	for i := 0; i < f.numPlanes(); i++ {
+		w, h := f.planeDimensions(i)
+		if f.BitDepth == 8 {
+			f.Buf[i] = make([]byte, w*h)
+		} else {
+			f.Buf16[i] = make([]uint16, w*h)
+		}
+	}
+}
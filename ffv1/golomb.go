@@ -0,0 +1,146 @@
+package ffv1
+
+// bitReader is a big-endian MSB-first bit reader used by the Golomb-Rice
+// coder path (coder_type == CoderGolombRice).
+type bitReader struct {
+	buf  []byte
+	pos  int // bit position
+}
+
+func newBitReader(buf []byte) *bitReader {
+	return &bitReader{buf: buf}
+}
+
+func (r *bitReader) readBit() int {
+	byteIdx := r.pos >> 3
+	if byteIdx >= len(r.buf) {
+		r.pos++
+		return 0
+	}
+	bit := int(r.buf[byteIdx]>>(7-uint(r.pos&7))) & 1
+	r.pos++
+	return bit
+}
+
+func (r *bitReader) readBits(n int) int {
+	v := 0
+	for i := 0; i < n; i++ {
+		v = v<<1 | r.readBit()
+	}
+	return v
+}
+
+// bitWriter is the putBits counterpart of bitReader.
+type bitWriter struct {
+	buf  []byte
+	cur  byte
+	nbit uint
+}
+
+func newBitWriter() *bitWriter {
+	return &bitWriter{}
+}
+
+func (w *bitWriter) writeBit(b int) {
+	w.cur = w.cur<<1 | byte(b&1)
+	w.nbit++
+	if w.nbit == 8 {
+		w.buf = append(w.buf, w.cur)
+		w.cur = 0
+		w.nbit = 0
+	}
+}
+
+func (w *bitWriter) writeBits(v, n int) {
+	for i := n - 1; i >= 0; i-- {
+		w.writeBit((v >> uint(i)) & 1)
+	}
+}
+
+func (w *bitWriter) flush() []byte {
+	if w.nbit > 0 {
+		w.buf = append(w.buf, w.cur<<(8-w.nbit))
+		w.cur = 0
+		w.nbit = 0
+	}
+	return w.buf
+}
+
+// golombState tracks the adaptive Rice parameter k for one context, along
+// with the sample count and magnitude sum adapt uses to update k.
+type golombState struct {
+	k     int
+	count int
+	sum   int
+}
+
+// decode reads one Golomb-Rice coded residual (quotient in unary, k-bit
+// remainder) and adapts k for the next call.
+func (s *golombState) decode(r *bitReader) int {
+	// maxUnaryRun bounds the unary quotient prefix so a corrupt or truncated
+	// stream can't spin forever (readBit returns 0 past the end of buf); it
+	// is far above any quotient encode can actually emit (zig-zag of a
+	// 16-bit residual at k=0, the smallest Rice parameter, tops out well
+	// under 1<<17), so it never truncates a genuine codeword.
+	const maxUnaryRun = 1 << 20
+
+	q := 0
+	for r.readBit() == 0 {
+		q++
+		if q > maxUnaryRun {
+			break
+		}
+	}
+	rem := 0
+	if s.k > 0 {
+		rem = r.readBits(s.k)
+	}
+	v := q<<uint(s.k) | rem
+	// Map back from zig-zag.
+	if v&1 != 0 {
+		v = -(v + 1) >> 1
+	} else {
+		v = v >> 1
+	}
+	s.adapt(v)
+	return v
+}
+
+// encode is the putBits counterpart of decode.
+func (s *golombState) encode(w *bitWriter, v int) {
+	var zz int
+	if v >= 0 {
+		zz = v << 1
+	} else {
+		zz = -v<<1 - 1
+	}
+	q := zz >> uint(s.k)
+	for i := 0; i < q; i++ {
+		w.writeBit(0)
+	}
+	w.writeBit(1)
+	if s.k > 0 {
+		w.writeBits(zz&((1<<uint(s.k))-1), s.k)
+	}
+	s.adapt(v)
+}
+
+// adapt follows FFV1's rule of thumb: grow k when the running sum of
+// magnitudes outpaces the sample count, shrink it otherwise.
+func (s *golombState) adapt(v int) {
+	abs := v
+	if abs < 0 {
+		abs = -abs
+	}
+	s.sum += abs
+	s.count++
+	if s.count == 1<<5 {
+		if s.sum > s.count<<(uint(s.k)+1) {
+			s.k++
+		} else if s.k > 0 && s.sum<<1 < s.count<<uint(s.k) {
+			s.k--
+		}
+		s.sum >>= 1
+		s.count >>= 1
+	}
+}
@@ -0,0 +1,180 @@
+package ffv1
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+)
+
+// Image returns frame as a standard library image.Image, so decoded frames
+// can be plugged directly into Go's image/draw pipeline (thumbnailing,
+// scaling, PNG encoding, ...).
+//
+// 8-bit YCbCr frames become *image.YCbCr (or *image.NYCbCrA with an alpha
+// plane); 8-bit RGB frames become *image.RGBA. Anything above 8 bits
+// becomes a YCbCr16 or RGB48, this package's own image.Image
+// implementations, since the standard library has no 16-bit YCbCr/RGB
+// image type.
+func (f *Frame) Image() (image.Image, error) {
+	ratio, err := f.subsampleRatio()
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case f.Colorspace == ColorspaceYCbCr && f.BitDepth == 8 && !f.HasAlpha:
+		return &image.YCbCr{
+			Y:              f.Buf[0],
+			Cb:             f.Buf[1],
+			Cr:             f.Buf[2],
+			YStride:        f.Width,
+			CStride:        f.Width >> uint(f.Log2ChromaW),
+			SubsampleRatio: ratio,
+			Rect:           image.Rect(0, 0, f.Width, f.Height),
+		}, nil
+
+	case f.Colorspace == ColorspaceYCbCr && f.BitDepth == 8 && f.HasAlpha:
+		return &image.NYCbCrA{
+			YCbCr: image.YCbCr{
+				Y:              f.Buf[0],
+				Cb:             f.Buf[1],
+				Cr:             f.Buf[2],
+				YStride:        f.Width,
+				CStride:        f.Width >> uint(f.Log2ChromaW),
+				SubsampleRatio: ratio,
+				Rect:           image.Rect(0, 0, f.Width, f.Height),
+			},
+			A:       f.Buf[3],
+			AStride: f.Width,
+		}, nil
+
+	case f.Colorspace == ColorspaceYCbCr && f.BitDepth > 8:
+		return &YCbCr16{
+			Y: f.Buf16[0], Cb: f.Buf16[1], Cr: f.Buf16[2],
+			YStride:        f.Width,
+			CStride:        f.Width >> uint(f.Log2ChromaW),
+			SubsampleRatio: ratio,
+			Rect:           image.Rect(0, 0, f.Width, f.Height),
+			BitDepth:       f.BitDepth,
+		}, nil
+
+	case f.Colorspace != ColorspaceYCbCr && f.BitDepth == 8:
+		return rgbaFromPlanes(f), nil
+
+	case f.Colorspace != ColorspaceYCbCr && f.BitDepth > 8:
+		return &RGB48{
+			R: f.Buf16[0], G: f.Buf16[1], B: f.Buf16[2],
+			Stride:   f.Width,
+			Rect:     image.Rect(0, 0, f.Width, f.Height),
+			BitDepth: f.BitDepth,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("ffv1: no image.Image mapping for colorspace %d at %d bits", f.Colorspace, f.BitDepth)
+}
+
+func (f *Frame) subsampleRatio() (image.YCbCrSubsampleRatio, error) {
+	switch {
+	case f.Log2ChromaW == 0 && f.Log2ChromaH == 0:
+		return image.YCbCrSubsampleRatio444, nil
+	case f.Log2ChromaW == 1 && f.Log2ChromaH == 0:
+		return image.YCbCrSubsampleRatio422, nil
+	case f.Log2ChromaW == 1 && f.Log2ChromaH == 1:
+		return image.YCbCrSubsampleRatio420, nil
+	case f.Log2ChromaW == 0 && f.Log2ChromaH == 1:
+		return image.YCbCrSubsampleRatio440, nil
+	default:
+		return 0, fmt.Errorf("ffv1: unsupported chroma subsampling log2(%d,%d)", f.Log2ChromaW, f.Log2ChromaH)
+	}
+}
+
+func rgbaFromPlanes(f *Frame) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, f.Width, f.Height))
+	for y := 0; y < f.Height; y++ {
+		for x := 0; x < f.Width; x++ {
+			i := y*f.Width + x
+			a := byte(0xFF)
+			if f.HasAlpha {
+				a = f.Buf[3][i]
+			}
+			img.SetRGBA(x, y, color.RGBA{R: f.Buf[0][i], G: f.Buf[1][i], B: f.Buf[2][i], A: a})
+		}
+	}
+	return img
+}
+
+// YCbCr16 is a 9-to-16-bit-per-sample analogue of image.YCbCr; the
+// standard library has no such type.
+type YCbCr16 struct {
+	Y, Cb, Cr      []uint16
+	YStride        int
+	CStride        int
+	SubsampleRatio image.YCbCrSubsampleRatio
+	Rect           image.Rectangle
+	BitDepth       int
+}
+
+// ColorModel implements image.Image.
+func (p *YCbCr16) ColorModel() color.Model { return color.YCbCrModel }
+
+// Bounds implements image.Image.
+func (p *YCbCr16) Bounds() image.Rectangle { return p.Rect }
+
+// At implements image.Image, shifting samples down to 8 bits to fit the
+// color.YCbCr it returns.
+func (p *YCbCr16) At(x, y int) color.Color {
+	if !(image.Point{x, y}.In(p.Rect)) {
+		return color.YCbCr{}
+	}
+	shift := uint(p.BitDepth - 8)
+	yi := y*p.YStride + x
+	cx, cy := p.chromaCoord(x, y)
+	ci := cy*p.CStride + cx
+	return color.YCbCr{
+		Y:  byte(p.Y[yi] >> shift),
+		Cb: byte(p.Cb[ci] >> shift),
+		Cr: byte(p.Cr[ci] >> shift),
+	}
+}
+
+func (p *YCbCr16) chromaCoord(x, y int) (int, int) {
+	switch p.SubsampleRatio {
+	case image.YCbCrSubsampleRatio422:
+		return x / 2, y
+	case image.YCbCrSubsampleRatio420:
+		return x / 2, y / 2
+	case image.YCbCrSubsampleRatio440:
+		return x, y / 2
+	default:
+		return x, y
+	}
+}
+
+// RGB48 is a 9-to-16-bit-per-sample, alpha-less analogue of image.RGBA64.
+type RGB48 struct {
+	R, G, B  []uint16
+	Stride   int
+	Rect     image.Rectangle
+	BitDepth int
+}
+
+// ColorModel implements image.Image.
+func (p *RGB48) ColorModel() color.Model { return color.RGBA64Model }
+
+// Bounds implements image.Image.
+func (p *RGB48) Bounds() image.Rectangle { return p.Rect }
+
+// At implements image.Image.
+func (p *RGB48) At(x, y int) color.Color {
+	if !(image.Point{x, y}.In(p.Rect)) {
+		return color.RGBA64{}
+	}
+	shift := uint(16 - p.BitDepth)
+	i := y*p.Stride + x
+	return color.RGBA64{
+		R: p.R[i] << shift,
+		G: p.G[i] << shift,
+		B: p.B[i] << shift,
+		A: 0xFFFF,
+	}
+}
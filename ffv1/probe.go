@@ -0,0 +1,63 @@
+package ffv1
+
+// StreamInfo summarizes an FFV1 stream's configuration without decoding
+// any pixels, for callers (transcoders, thumbnailers, muxers) that just
+// need to route the stream correctly.
+type StreamInfo struct {
+	Version      Version
+	MicroVersion int
+
+	CoderType  CoderType
+	Colorspace Colorspace
+
+	BitDepth     int
+	HasAlpha     bool
+	Log2ChromaW  int
+	Log2ChromaH  int
+
+	NumHSlices int
+	NumVSlices int
+
+	// IntraOnly reports whether every frame of the stream is coded
+	// independently (no inter prediction). FFV1 has no inter-prediction
+	// mode, so this is always true; it is surfaced so callers written
+	// against other codecs' Probe-style APIs don't need a special case.
+	IntraOnly bool
+
+	SliceCRC bool
+}
+
+// Probe parses extradata and returns a StreamInfo describing the stream,
+// without requiring a first packet.
+func Probe(extradata []byte) (*StreamInfo, error) {
+	cr, err := ParseConfigurationRecord(extradata)
+	if err != nil {
+		return nil, err
+	}
+	return streamInfoFromRecord(cr), nil
+}
+
+// ProbePacket parses extradata and returns the same information as Probe.
+// firstPacket is not read or validated; it is accepted so callers have a
+// single entry point that works across codecs with packet-dependent
+// extradata.
+func ProbePacket(extradata, firstPacket []byte) (*StreamInfo, error) {
+	return Probe(extradata)
+}
+
+func streamInfoFromRecord(cr *ConfigurationRecord) *StreamInfo {
+	return &StreamInfo{
+		Version:      cr.Version,
+		MicroVersion: cr.MicroVersion,
+		CoderType:    cr.CoderType,
+		Colorspace:   cr.Colorspace,
+		BitDepth:     cr.BitsPerRawSample,
+		HasAlpha:     cr.ExtraPlane,
+		Log2ChromaW:  cr.Log2ChromaW,
+		Log2ChromaH:  cr.Log2ChromaH,
+		NumHSlices:   cr.NumHSlices,
+		NumVSlices:   cr.NumVSlices,
+		IntraOnly:    true,
+		SliceCRC:     cr.SliceCRC,
+	}
+}
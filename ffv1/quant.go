@@ -0,0 +1,106 @@
+package ffv1
+
+// quantTableSet holds one of a stream's quantization table sets: five
+// per-context-dimension lookup tables mapping a pixel-gradient value to a
+// small signed quantization level, plus the context count they imply (the
+// number of adaptive states both the range and Golomb-Rice coders keep).
+type quantTableSet struct {
+	tables          [5][]int8
+	contextCount    int
+	stateTransition [256]uint8
+}
+
+func readQuantTableSet(rc *rangeCoder, st *stateTransition, state []uint8) quantTableSet {
+	var qt quantTableSet
+	for i := 0; i < 5; i++ {
+		qt.tables[i] = make([]int8, 256)
+		j := 0
+		for j < 128 {
+			v := rc.getSymbol(st, state, true)
+			run := rc.getSymbol(st, state, false) + 1
+			for k := 0; k < run && j < 128; k++ {
+				qt.tables[i][j] = int8(v)
+				qt.tables[i][255-j] = int8(-v)
+				j++
+			}
+		}
+	}
+	qt.contextCount = computeContextCount(qt.tables)
+	return qt
+}
+
+func writeQuantTableSet(rc *rangeCoder, st *stateTransition, state []uint8, qt *quantTableSet) {
+	for i := 0; i < 5; i++ {
+		j := 0
+		for j < 128 {
+			v := int(qt.tables[i][j])
+			run := 1
+			for j+run < 128 && qt.tables[i][j+run] == int8(v) {
+				run++
+			}
+			rc.putSymbol(st, state, v, true)
+			rc.putSymbol(st, state, run-1, false)
+			j += run
+		}
+	}
+}
+
+func contextsInTable(t []int8) int {
+	max := 0
+	for _, v := range t {
+		if int(v) > max {
+			max = int(v)
+		}
+		if int(-v) > max {
+			max = int(-v)
+		}
+	}
+	return 2*max + 1
+}
+
+func computeContextCount(tables [5][]int8) int {
+	n := 1
+	for _, t := range tables {
+		n *= contextsInTable(t)
+	}
+	return (n + 1) / 2
+}
+
+// defaultQuantTableSet returns the table set used when a stream doesn't
+// carry a custom one, built with the small "5-context" shape the reference
+// encoder defaults to.
+func defaultQuantTableSet() quantTableSet {
+	levels := []int8{0, 1, 1, 1, 1, 1, 2, 2, 2, 2, 2, 3, 3, 3, 3, 3}
+	table := make([]int8, 256)
+	for i := 0; i < 128; i++ {
+		v := levels[min(i/8, len(levels)-1)]
+		table[i] = v
+		table[255-i] = -v
+	}
+	var qt quantTableSet
+	for i := range qt.tables {
+		qt.tables[i] = table
+	}
+	qt.contextCount = computeContextCount(qt.tables)
+	return qt
+}
+
+// quantize maps a pixel gradient through table i of the set.
+func (qt *quantTableSet) quantize(tableIdx int, diff int) int {
+	idx := diff & 0xFF
+	return int(qt.tables[tableIdx][idx])
+}
+
+// context computes the combined small-context index for a pixel from its
+// five neighbour gradients, as FFV1 §3.8 describes.
+func (qt *quantTableSet) context(d0, d1, d2, d3, d4 int) (idx int, sign int) {
+	c := qt.quantize(0, d0)
+	c = c*contextsInTable(qt.tables[1]) + qt.quantize(1, d1)
+	c = c*contextsInTable(qt.tables[2]) + qt.quantize(2, d2)
+	c = c*contextsInTable(qt.tables[3]) + qt.quantize(3, d3)
+	c = c*contextsInTable(qt.tables[4]) + qt.quantize(4, d4)
+	if c < 0 {
+		return -c, -1
+	}
+	return c, 1
+}
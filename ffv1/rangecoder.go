@@ -0,0 +1,248 @@
+package ffv1
+
+// rangeCoder implements FFV1's binary range coder. A single implementation
+// backs both the reader and the writer: which direction is in use depends
+// on whether getRAC/getSymbol or putRAC/putSymbol is called.
+//
+// The coder is a byte-renormalized binary arithmetic coder: each bit is
+// coded against an adaptive 8-bit probability ("state") drawn from a
+// 256-entry transition table, which is itself either the bitstream default
+// or a custom table carried in the configuration record.
+type rangeCoder struct {
+	buf []byte
+	pos int
+
+	low   uint32
+	rng   uint32
+	outByte  int
+	outCount int
+}
+
+// defaultStateTransition is the default state transition table used when
+// coder_type is CoderRangeDefault. Index i is the current state; the value
+// is the state reached after observing a "0" bit. The "1" transition is
+// 256-defaultStateTransition[256-i], mirroring the table around its center.
+var defaultStateTransition = [256]uint8{
+	0, 10, 10, 10, 10, 16, 16, 16, 18, 19, 20, 21, 22, 23, 24, 25,
+	26, 27, 28, 29, 30, 31, 32, 33, 34, 35, 36, 37, 38, 39, 40, 41,
+	42, 43, 44, 45, 46, 47, 48, 49, 50, 51, 52, 53, 54, 55, 56, 57,
+	58, 59, 60, 61, 62, 63, 64, 65, 66, 67, 68, 69, 70, 71, 72, 73,
+	74, 75, 76, 77, 78, 79, 80, 81, 82, 83, 84, 85, 86, 87, 88, 89,
+	90, 91, 92, 93, 94, 95, 96, 97, 98, 99, 100, 101, 102, 103, 104, 105,
+	106, 107, 108, 109, 110, 111, 112, 113, 114, 115, 116, 117, 118, 119, 120, 121,
+	122, 123, 124, 125, 126, 127, 128, 129, 130, 131, 132, 133, 134, 135, 136, 137,
+	138, 139, 140, 141, 142, 143, 144, 145, 146, 147, 148, 149, 150, 151, 152, 153,
+	154, 155, 156, 157, 158, 159, 160, 161, 162, 163, 164, 165, 166, 167, 168, 169,
+	170, 171, 172, 173, 174, 175, 176, 177, 178, 179, 180, 181, 182, 183, 184, 185,
+	186, 187, 188, 189, 190, 191, 192, 193, 194, 195, 196, 197, 198, 199, 200, 201,
+	202, 203, 204, 205, 206, 207, 208, 209, 210, 211, 212, 213, 214, 215, 216, 217,
+	218, 219, 220, 221, 222, 223, 224, 225, 226, 227, 228, 229, 230, 231, 232, 233,
+	234, 235, 236, 237, 238, 239, 240, 241, 242, 243, 244, 245, 246, 247, 248, 249,
+	250, 251, 252, 253, 254, 255, 255, 255,
+	255, 255, 255, 255, 255, 255, 255, 255,
+}
+
+// stateTransition pairs the "0" and "1" transition tables built from a
+// 256-entry default/custom table carried in the configuration record.
+type stateTransition struct {
+	zero [256]uint8
+	one  [256]uint8
+}
+
+func buildStateTransition(table [256]uint8) *stateTransition {
+	st := &stateTransition{}
+	for i := 0; i < 256; i++ {
+		st.zero[i] = table[i]
+	}
+	for i := 1; i < 256; i++ {
+		st.one[i] = uint8(256 - int(st.zero[256-i]))
+	}
+	st.one[0] = 0
+	return st
+}
+
+func newRangeDecoder(buf []byte) *rangeCoder {
+	rc := &rangeCoder{buf: buf, rng: 0xFF00}
+	var b0, b1 byte
+	if len(buf) > 0 {
+		b0 = buf[0]
+	}
+	if len(buf) > 1 {
+		b1 = buf[1]
+	}
+	rc.low = uint32(b0)<<8 | uint32(b1)
+	rc.pos = 2
+	return rc
+}
+
+func newRangeEncoder() *rangeCoder {
+	return &rangeCoder{rng: 0xFF00, outByte: -1}
+}
+
+func (rc *rangeCoder) refillDecoder() {
+	for rc.rng < 0x100 {
+		rc.rng <<= 8
+		rc.low <<= 8
+		if rc.pos < len(rc.buf) {
+			rc.low |= uint32(rc.buf[rc.pos])
+			rc.pos++
+		}
+		rc.low &= 0xFFFF
+	}
+}
+
+// getRAC decodes a single bit against the adaptive state at *state, which
+// is updated in place.
+func (rc *rangeCoder) getRAC(st *stateTransition, state *uint8) int {
+	r1 := (rc.rng * uint32(*state)) >> 8
+	if rc.low < r1 {
+		rc.rng = r1
+		*state = st.zero[*state]
+		rc.refillDecoder()
+		return 0
+	}
+	rc.low -= r1
+	rc.rng -= r1
+	*state = st.one[*state]
+	rc.refillDecoder()
+	return 1
+}
+
+// putRAC is the encoder-side counterpart of getRAC.
+func (rc *rangeCoder) putRAC(st *stateTransition, state *uint8, bit int) {
+	r1 := (rc.rng * uint32(*state)) >> 8
+	if bit == 0 {
+		rc.rng = r1
+		*state = st.zero[*state]
+	} else {
+		rc.low += r1
+		rc.rng -= r1
+		*state = st.one[*state]
+	}
+	rc.renormEncoder()
+}
+
+// renormEncoder shifts whole bytes of low out to the output buffer whenever
+// rng underflows below 0x100. Emission is delayed by one byte
+// (rc.outByte/rc.outCount) because adding r1 into low can still carry into a
+// byte already "decided" here — the delayed byte, plus any run of pending
+// 0xFF bytes, is only committed once a later renormalization resolves
+// whether that carry actually happened.
+func (rc *rangeCoder) renormEncoder() {
+	for rc.rng < 0x100 {
+		switch {
+		case rc.outByte < 0:
+			rc.outByte = int(rc.low >> 8)
+		case rc.low <= 0xFF00:
+			rc.outputByte(byte(rc.outByte))
+			for ; rc.outCount > 0; rc.outCount-- {
+				rc.outputByte(0xFF)
+			}
+			rc.outByte = int(rc.low >> 8)
+		case rc.low >= 0x10000:
+			rc.outputByte(byte(rc.outByte + 1))
+			for ; rc.outCount > 0; rc.outCount-- {
+				rc.outputByte(0x00)
+			}
+			rc.outByte = int(rc.low>>8) & 0xFF
+		default:
+			rc.outCount++
+		}
+		rc.low = (rc.low << 8) & 0xFFFF
+		rc.rng <<= 8
+	}
+}
+
+func (rc *rangeCoder) outputByte(b byte) {
+	rc.buf = append(rc.buf, b)
+}
+
+// flush resolves and emits the encoder's remaining buffered state (the
+// pending outByte/outCount from renormEncoder, plus low's last two bytes)
+// and returns the encoded bytes.
+func (rc *rangeCoder) flush() []byte {
+	rc.rng = 0xFF
+	rc.low += 0xFF
+	rc.renormEncoder()
+	rc.rng = 0xFF
+	rc.renormEncoder()
+	return rc.buf
+}
+
+// newSymbolState allocates a 32-entry adaptive context state array for use
+// with getSymbol/putSymbol, initialized to the neutral probability (128)
+// every context must start at.
+func newSymbolState() []uint8 {
+	state := make([]uint8, 32)
+	for i := range state {
+		state[i] = 128
+	}
+	return state
+}
+
+// getSymbol decodes an FFV1 "symbol": a unary-ish exponent/mantissa coded
+// (optionally signed) integer, using a 32-entry context state array as the
+// bitstream describes.
+func (rc *rangeCoder) getSymbol(st *stateTransition, state []uint8, signed bool) int {
+	if rc.getRAC(st, &state[0]) != 0 {
+		return 0
+	}
+
+	e := 0
+	for rc.getRAC(st, &state[1+min(e, 9)]) != 0 {
+		e++
+		if e > 31 {
+			break
+		}
+	}
+
+	a := 1
+	for i := e - 1; i >= 0; i-- {
+		a += a + rc.getRAC(st, &state[22+min(i, 9)])
+	}
+
+	if signed && rc.getRAC(st, &state[11+min(e, 10)]) != 0 {
+		return -a
+	}
+	return a
+}
+
+// putSymbol is the encoder-side counterpart of getSymbol.
+func (rc *rangeCoder) putSymbol(st *stateTransition, state []uint8, v int, signed bool) {
+	if v == 0 {
+		rc.putRAC(st, &state[0], 1)
+		return
+	}
+	rc.putRAC(st, &state[0], 0)
+
+	a := v
+	neg := 0
+	if a < 0 {
+		a = -a
+		neg = 1
+	}
+
+	e := 0
+	for t := a; t > 1; t >>= 1 {
+		e++
+	}
+	for i := 0; i < e; i++ {
+		rc.putRAC(st, &state[1+min(i, 9)], 1)
+	}
+	rc.putRAC(st, &state[1+min(e, 9)], 0)
+
+	for i := e - 1; i >= 0; i-- {
+		rc.putRAC(st, &state[22+min(i, 9)], (a>>uint(i))&1)
+	}
+
+	if signed {
+		rc.putRAC(st, &state[11+min(e, 10)], neg)
+	}
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,200 @@
+package ffv1
+
+// isRGBColorspace reports whether frame's planes are RGB rather than
+// YCbCr — the same predicate Image() uses to pick an RGB decode path.
+func isRGBColorspace(frame *Frame) bool {
+	return frame.Colorspace != ColorspaceYCbCr
+}
+
+// codingPlane is one bitstream-coded plane of a slice: sample/write access
+// its values by absolute plane coordinates, (x0, y0, w, h) bound the
+// slice's rectangle within it, and bitDepth is the range prediction
+// residuals wrap into.
+type codingPlane struct {
+	sample       func(x, y int) int
+	write        func(x, y, v int)
+	x0, y0, w, h int
+	bitDepth     int
+}
+
+// rgbDiffScratch holds one slice's worth of a decoded RGB reversible color
+// transform plane. Two of FFV1's three RCT components (Cb = B-G, Cr = R-G)
+// need one bit more range than a literal sample to hold losslessly, which
+// Frame's own Buf/Buf16 (sized to exactly BitDepth bits) can't provide — so
+// decoding works all three RCT planes in same-sized int32 scratch buffers
+// instead, and finishRCTDecode combines them once the whole slice is
+// reconstructed.
+type rgbDiffScratch struct {
+	buf       []int32
+	x0, y0, w int
+}
+
+func newRGBDiffScratch(x0, y0, w, h int) *rgbDiffScratch {
+	return &rgbDiffScratch{buf: make([]int32, w*h), x0: x0, y0: y0, w: w}
+}
+
+func (s *rgbDiffScratch) sample(x, y int) int { return int(s.buf[(y-s.y0)*s.w+(x-s.x0)]) }
+func (s *rgbDiffScratch) write(x, y, v int)   { s.buf[(y-s.y0)*s.w+(x-s.x0)] = int32(v) }
+
+// signedFromWrapped recovers a value's true signed representative from its
+// non-negative residue modulo 2^bits, the inverse of the "& mask" wrapping
+// rctForward applies to Cb/Cr. This is exactly wrapResidual's half/full
+// centering, reused here under its own name since that call site is about
+// recovering a sign rather than folding a residual.
+func signedFromWrapped(v, bits int) int {
+	return wrapResidual(v, bits)
+}
+
+// rctForward applies FFV1's reversible color transform (RFC 9043 §3.2) to
+// one literal RGB sample, returning the coded-domain (Y, Cb, Cr) FFV1 codes
+// instead of literal samples. Matching the reference codec, G doesn't pass
+// through unchanged: Y also folds in a quarter of Cb+Cr, both to spread G's
+// energy (which otherwise compresses worse, being the strongest-correlated
+// channel) and because that is what a spec-conformant decoder expects to
+// undo.
+func rctForward(r, g, b, bitDepth int) (y, cb, cr int) {
+	gmask := (1 << uint(bitDepth)) - 1
+	cmask := (1 << uint(bitDepth+1)) - 1
+	cb = b - g
+	cr = r - g
+	y = (g + ((cb + cr) >> 2)) & gmask
+	cb &= cmask
+	cr &= cmask
+	return y, cb, cr
+}
+
+// rctInverse undoes rctForward, recovering literal (r, g, b) from the
+// coded-domain (y, cb, cr) a slice decoded.
+func rctInverse(y, cb, cr, bitDepth int) (r, g, b int) {
+	gmask := (1 << uint(bitDepth)) - 1
+	cb = signedFromWrapped(cb, bitDepth+1)
+	cr = signedFromWrapped(cr, bitDepth+1)
+	g = (y - ((cb + cr) >> 2)) & gmask
+	b = (cb + g) & gmask
+	r = (cr + g) & gmask
+	return r, g, b
+}
+
+// rgbCodingPlanes returns, in bitstream order, the coding-domain planes a
+// slice's decode loop codes. For YCbCr (and any other non-RGB) frame this
+// is just its own planes, literally. For RGB frames, FFV1 codes the
+// reversible color transform's (Y, Cb, Cr) components instead of literal
+// samples — decoded here into scratch buffers, since Y, Cb and Cr are each
+// predicted from their own previously decoded neighbours, independent of
+// the other two components, and only combined afterwards by
+// finishRCTDecode to recover literal R, G and B.
+func rgbCodingPlanes(frame *Frame, x, y, w, h int) []codingPlane {
+	n := frame.numPlanes()
+	planes := make([]codingPlane, n)
+	if !isRGBColorspace(frame) {
+		for p := 0; p < n; p++ {
+			px, py, pwClip, phClip := planeRect(x, y, w, h, p, frame)
+			planes[p] = codingPlane{
+				sample: framePlaneSampler(frame, p), write: framePlaneWriter(frame, p),
+				x0: px, y0: py, w: pwClip, h: phClip, bitDepth: frame.BitDepth,
+			}
+		}
+		return planes
+	}
+
+	px, py, pwClip, phClip := planeRect(x, y, w, h, 1, frame)
+	yScratch := newRGBDiffScratch(px, py, pwClip, phClip)
+	planes[0] = codingPlane{
+		sample: yScratch.sample, write: yScratch.write,
+		x0: px, y0: py, w: pwClip, h: phClip, bitDepth: frame.BitDepth,
+	}
+	cb := newRGBDiffScratch(px, py, pwClip, phClip)
+	planes[1] = codingPlane{
+		sample: cb.sample, write: cb.write,
+		x0: px, y0: py, w: pwClip, h: phClip, bitDepth: frame.BitDepth + 1,
+	}
+	cr := newRGBDiffScratch(px, py, pwClip, phClip)
+	planes[2] = codingPlane{
+		sample: cr.sample, write: cr.write,
+		x0: px, y0: py, w: pwClip, h: phClip, bitDepth: frame.BitDepth + 1,
+	}
+	if n > 3 {
+		apx, apy, apwClip, aphClip := planeRect(x, y, w, h, 3, frame)
+		planes[3] = codingPlane{
+			sample: framePlaneSampler(frame, 3), write: framePlaneWriter(frame, 3),
+			x0: apx, y0: apy, w: apwClip, h: aphClip, bitDepth: frame.BitDepth,
+		}
+	}
+	return planes
+}
+
+// finishRCTDecode reconstructs literal R, G and B for an RGB slice from its
+// decoded Y/Cb/Cr planes (planes[0..2]), writing them into frame.Buf[0..2]
+// per Frame's literal R/G/B layout. A no-op for non-RGB frames.
+func finishRCTDecode(frame *Frame, x, y, w, h int, planes []codingPlane) {
+	if !isRGBColorspace(frame) {
+		return
+	}
+	rWrite := framePlaneWriter(frame, 0)
+	gWrite := framePlaneWriter(frame, 1)
+	bWrite := framePlaneWriter(frame, 2)
+	yp, cb, cr := planes[0], planes[1], planes[2]
+	for j := 0; j < yp.h; j++ {
+		for i := 0; i < yp.w; i++ {
+			xx, yy := yp.x0+i, yp.y0+j
+			r, g, b := rctInverse(yp.sample(xx, yy), cb.sample(xx, yy), cr.sample(xx, yy), frame.BitDepth)
+			rWrite(xx, yy, r)
+			gWrite(xx, yy, g)
+			bWrite(xx, yy, b)
+		}
+	}
+}
+
+// encodeCodingPlanes is rgbCodingPlanes' encoder-side counterpart. The
+// whole frame is already populated before slicing begins, so for RGB frames
+// Y, Cb and Cr are computed from the literal R/G/B planes up front, once per
+// pixel of the slice, into scratch buffers.
+func encodeCodingPlanes(frame *Frame, x, y, w, h int) []codingPlane {
+	n := frame.numPlanes()
+	planes := make([]codingPlane, n)
+	if !isRGBColorspace(frame) {
+		for p := 0; p < n; p++ {
+			px, py, pwClip, phClip := planeRect(x, y, w, h, p, frame)
+			planes[p] = codingPlane{sample: framePlaneSampler(frame, p), x0: px, y0: py, w: pwClip, h: phClip, bitDepth: frame.BitDepth}
+		}
+		return planes
+	}
+
+	px, py, pwClip, phClip := planeRect(x, y, w, h, 1, frame)
+	rSample := framePlaneSampler(frame, 0)
+	gSample := framePlaneSampler(frame, 1)
+	bSample := framePlaneSampler(frame, 2)
+
+	// planeNeighbours reads up to six causal neighbours and the coding loop
+	// reads the current sample, so each value is read up to seven times
+	// per pixel; precompute Y/Cb/Cr
+	// once per pixel into scratch buffers rather than re-deriving them from
+	// R/G/B on every read.
+	yScratch := newRGBDiffScratch(px, py, pwClip, phClip)
+	cbScratch := newRGBDiffScratch(px, py, pwClip, phClip)
+	crScratch := newRGBDiffScratch(px, py, pwClip, phClip)
+	for j := 0; j < phClip; j++ {
+		for i := 0; i < pwClip; i++ {
+			xx, yy := px+i, py+j
+			yv, cb, cr := rctForward(rSample(xx, yy), gSample(xx, yy), bSample(xx, yy), frame.BitDepth)
+			yScratch.write(xx, yy, yv)
+			cbScratch.write(xx, yy, cb)
+			crScratch.write(xx, yy, cr)
+		}
+	}
+
+	planes[0] = codingPlane{
+		sample: yScratch.sample, x0: px, y0: py, w: pwClip, h: phClip, bitDepth: frame.BitDepth,
+	}
+	planes[1] = codingPlane{
+		sample: cbScratch.sample, x0: px, y0: py, w: pwClip, h: phClip, bitDepth: frame.BitDepth + 1,
+	}
+	planes[2] = codingPlane{
+		sample: crScratch.sample, x0: px, y0: py, w: pwClip, h: phClip, bitDepth: frame.BitDepth + 1,
+	}
+	if n > 3 {
+		apx, apy, apwClip, aphClip := planeRect(x, y, w, h, 3, frame)
+		planes[3] = codingPlane{sample: framePlaneSampler(frame, 3), x0: apx, y0: apy, w: apwClip, h: aphClip, bitDepth: frame.BitDepth}
+	}
+	return planes
+}
@@ -0,0 +1,166 @@
+package ffv1
+
+import (
+	"fmt"
+	"hash/crc32"
+)
+
+func crc32OfSlice(b []byte) uint32 {
+	return crc32.ChecksumIEEE(b)
+}
+
+// sliceRect returns the luma pixel rectangle owned by slice (row, col) of
+// an numH x numV grid evenly dividing a width x height frame, with any
+// remainder pixels folded into the last row/column — the same scheme the
+// reference encoder uses for slice_width/slice_height.
+//
+// Boundaries are snapped to multiples of the chroma subsampling factor
+// (log2ChromaW/log2ChromaH) so that right-shifting a slice's luma
+// rectangle by those same shifts (planeRect) always yields a chroma
+// rectangle with no gap or overlap between neighbouring slices.
+func sliceRect(width, height, numH, numV, row, col, log2ChromaW, log2ChromaH int) (x, y, w, h int) {
+	alignW := 1 << uint(log2ChromaW)
+	alignH := 1 << uint(log2ChromaH)
+
+	blocksW := (width + alignW - 1) / alignW
+	blocksH := (height + alignH - 1) / alignH
+	baseBW, remBW := blocksW/numH, blocksW%numH
+	baseBH, remBH := blocksH/numV, blocksH%numV
+
+	colBlocks := baseBW
+	if col == numH-1 {
+		colBlocks += remBW
+	}
+	rowBlocks := baseBH
+	if row == numV-1 {
+		rowBlocks += remBH
+	}
+
+	x = col * baseBW * alignW
+	y = row * baseBH * alignH
+	w = colBlocks * alignW
+	h = rowBlocks * alignH
+	if x+w > width {
+		w = width - x
+	}
+	if y+h > height {
+		h = height - y
+	}
+	return x, y, w, h
+}
+
+// sliceHeaderPrefixSize is the size, in bytes, of the explicit length
+// prefix placed before a Golomb-Rice slice's range-coded header. FFV1's
+// slice header is always range-coded regardless of coder_type, but a real
+// decoder locates the byte at which the raw-bit Golomb-Rice payload begins
+// from the range coder's own bit position (RangeCoder/GetBitContext share
+// one pointer in the reference implementation). This codebase keeps the
+// range coder and bit reader/writer as separate, self-contained types, so
+// instead of reconstructing that shared bit-position bookkeeping, the
+// header's byte length is written explicitly. Range-coded slices need no
+// such prefix: their header and pixel data share one continuous range
+// coder stream, exactly as the reference decoder's header/payload read
+// does.
+const sliceHeaderPrefixSize = 2
+
+// writeSliceHeader range-codes a version 3 slice header onto rc: the
+// slice's pixel rectangle, one quant_table_set_index per coded plane (this
+// encoder only ever writes table 0) and slice_reset_context (always set,
+// since every slice starts its adaptive states fresh). It uses a state
+// array scoped to the header alone, as FFV1 §3.7's slice_state does.
+func writeSliceHeader(rc *rangeCoder, st *stateTransition, x, y, w, h, planeCount int) {
+	state := newSymbolState()
+	rc.putSymbol(st, state, x, false)
+	rc.putSymbol(st, state, y, false)
+	rc.putSymbol(st, state, w-1, false)
+	rc.putSymbol(st, state, h-1, false)
+	for i := 0; i < planeCount; i++ {
+		rc.putSymbol(st, state, 0, false)
+	}
+	rc.putSymbol(st, state, 1, false)
+}
+
+// writeGolombSliceHeader is writeSliceHeader's counterpart for
+// Golomb-Rice slices: the header is range-coded into its own short-lived
+// byte buffer (since the pixel data that follows uses a raw bit writer,
+// not the range coder), prefixed with its length per sliceHeaderPrefixSize.
+func writeGolombSliceHeader(st *stateTransition, x, y, w, h, planeCount int) []byte {
+	hrc := newRangeEncoder()
+	writeSliceHeader(hrc, st, x, y, w, h, planeCount)
+	header := hrc.flush()
+	out := make([]byte, sliceHeaderPrefixSize, sliceHeaderPrefixSize+len(header))
+	out[0] = byte(len(header) >> 8)
+	out[1] = byte(len(header))
+	return append(out, header...)
+}
+
+// readSliceHeader is writeSliceHeader's decoder-side counterpart.
+func readSliceHeader(rc *rangeCoder, st *stateTransition, planeCount int) (x, y, w, h int) {
+	state := newSymbolState()
+	x = rc.getSymbol(st, state, false)
+	y = rc.getSymbol(st, state, false)
+	w = rc.getSymbol(st, state, false) + 1
+	h = rc.getSymbol(st, state, false) + 1
+	for i := 0; i < planeCount; i++ {
+		_ = rc.getSymbol(st, state, false)
+	}
+	_ = rc.getSymbol(st, state, false)
+	return x, y, w, h
+}
+
+// sliceFooterSize is the size, in bytes, of the per-slice trailer appended
+// after each slice's coded data: a 24-bit big-endian length, plus (when
+// slice CRCs are enabled) a 32-bit CRC32 of the slice's coded bytes.
+func sliceFooterSize(crc bool) int {
+	if crc {
+		return 3 + 4
+	}
+	return 3
+}
+
+// muxSlices concatenates per-slice coded payloads into a single packet,
+// each followed by its footer, in raster (row-major) slice order.
+func muxSlices(slices [][]byte, crc bool) []byte {
+	var out []byte
+	for _, s := range slices {
+		out = append(out, s...)
+		n := len(s)
+		out = append(out, byte(n>>16), byte(n>>8), byte(n))
+		if crc {
+			c := crc32OfSlice(s)
+			out = append(out, byte(c>>24), byte(c>>16), byte(c>>8), byte(c))
+		}
+	}
+	return out
+}
+
+// demuxSlices splits a packet into its constituent slices' coded byte
+// ranges by walking the footers from the end of the buffer backwards, the
+// same way FFV1 v3 lets a decoder jump straight to any slice without
+// scanning the whole packet forward first. When crc is set, crcs[i] holds
+// the CRC32 recorded in slice i's footer for the caller to verify.
+func demuxSlices(data []byte, numSlices int, crc bool) (slices [][]byte, crcs []uint32, err error) {
+	footer := sliceFooterSize(crc)
+	slices = make([][]byte, numSlices)
+	if crc {
+		crcs = make([]uint32, numSlices)
+	}
+	end := len(data)
+	for i := numSlices - 1; i >= 0; i-- {
+		if end < footer {
+			return nil, nil, fmt.Errorf("ffv1: truncated packet, missing slice %d", i)
+		}
+		f := data[end-footer : end]
+		n := int(f[0])<<16 | int(f[1])<<8 | int(f[2])
+		start := end - footer - n
+		if start < 0 {
+			return nil, nil, fmt.Errorf("ffv1: corrupt slice footer for slice %d", i)
+		}
+		slices[i] = data[start : end-footer]
+		if crc {
+			crcs[i] = uint32(f[3])<<24 | uint32(f[4])<<16 | uint32(f[5])<<8 | uint32(f[6])
+		}
+		end = start
+	}
+	return slices, crcs, nil
+}
@@ -0,0 +1,135 @@
+package ffv1
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// Y4MHeader describes the stream-level parameters written once at the top
+// of a YUV4MPEG2 stream, before any FRAME chunks.
+type Y4MHeader struct {
+	Width  int
+	Height int
+
+	// FPSNumerator/FPSDenominator describe the frame rate ("F" tag). If
+	// both are zero, the rate is omitted from the header.
+	FPSNumerator   int
+	FPSDenominator int
+
+	// Interlace is the raw "I" tag value (e.g. "p", "t", "b"); defaults to
+	// "p" (progressive) if empty.
+	Interlace string
+
+	// AspectNumerator/AspectDenominator describe the "A" tag. If both are
+	// zero, the tag is omitted.
+	AspectNumerator   int
+	AspectDenominator int
+}
+
+// Y4MWriter writes decoded Frames out as a YUV4MPEG2 stream.
+type Y4MWriter struct {
+	w    *bufio.Writer
+	hdr  Y4MHeader
+	wroteHeader bool
+}
+
+// NewY4MWriter validates hdr and returns a Y4MWriter writing to w, ready to
+// accept frames via WriteFrame. Nothing is written to w until the first
+// WriteFrame call, which also writes the stream header.
+func NewY4MWriter(w io.Writer, hdr Y4MHeader) (*Y4MWriter, error) {
+	if hdr.Width <= 0 || hdr.Height <= 0 {
+		return nil, fmt.Errorf("ffv1: invalid Y4M dimensions %dx%d", hdr.Width, hdr.Height)
+	}
+	if hdr.Interlace == "" {
+		hdr.Interlace = "p"
+	}
+	yw := &Y4MWriter{w: bufio.NewWriter(w), hdr: hdr}
+	return yw, nil
+}
+
+// WriteFrame writes frame as one FRAME chunk. The first call also writes
+// the stream header, inferring the "C" colorspace tag from frame's bit
+// depth and chroma subsampling.
+func (yw *Y4MWriter) WriteFrame(frame *Frame) error {
+	if !yw.wroteHeader {
+		if err := yw.writeHeader(frame); err != nil {
+			return err
+		}
+		yw.wroteHeader = true
+	}
+
+	if _, err := yw.w.WriteString("FRAME\n"); err != nil {
+		return err
+	}
+
+	planes := 3
+	if frame.HasAlpha {
+		planes = 4
+	}
+	for p := 0; p < planes; p++ {
+		if frame.BitDepth == 8 {
+			if _, err := yw.w.Write(frame.Buf[p]); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := binary.Write(yw.w, binary.LittleEndian, frame.Buf16[p]); err != nil {
+			return err
+		}
+	}
+	return yw.w.Flush()
+}
+
+func (yw *Y4MWriter) writeHeader(frame *Frame) error {
+	tag, err := y4mColorspaceTag(frame)
+	if err != nil {
+		return err
+	}
+
+	header := fmt.Sprintf("YUV4MPEG2 W%d H%d I%s", yw.hdr.Width, yw.hdr.Height, yw.hdr.Interlace)
+	if yw.hdr.FPSNumerator > 0 && yw.hdr.FPSDenominator > 0 {
+		header += fmt.Sprintf(" F%d:%d", yw.hdr.FPSNumerator, yw.hdr.FPSDenominator)
+	}
+	if yw.hdr.AspectNumerator > 0 && yw.hdr.AspectDenominator > 0 {
+		header += fmt.Sprintf(" A%d:%d", yw.hdr.AspectNumerator, yw.hdr.AspectDenominator)
+	}
+	header += " C" + tag + "\n"
+
+	_, err = yw.w.WriteString(header)
+	return err
+}
+
+// y4mColorspaceTag derives the YUV4MPEG2 "C" tag value (e.g. 420p10, 444,
+// without the leading "C") from a Frame's chroma subsampling and bit depth.
+func y4mColorspaceTag(frame *Frame) (string, error) {
+	if frame.Colorspace != ColorspaceYCbCr {
+		return "", fmt.Errorf("ffv1: Y4M only supports YCbCr frames, got colorspace %d", frame.Colorspace)
+	}
+
+	var base string
+	switch {
+	case frame.Log2ChromaW == 1 && frame.Log2ChromaH == 1:
+		base = "420"
+	case frame.Log2ChromaW == 1 && frame.Log2ChromaH == 0:
+		base = "422"
+	case frame.Log2ChromaW == 0 && frame.Log2ChromaH == 0:
+		base = "444"
+	default:
+		return "", fmt.Errorf("ffv1: unsupported chroma subsampling log2(%d,%d) for Y4M",
+			frame.Log2ChromaW, frame.Log2ChromaH)
+	}
+	if frame.HasAlpha {
+		base += "alpha"
+	}
+
+	switch frame.BitDepth {
+	case 8:
+		return base, nil
+	case 9, 10, 12, 14, 16:
+		return fmt.Sprintf("%sp%d", base, frame.BitDepth), nil
+	default:
+		return "", fmt.Errorf("ffv1: unsupported bit depth %d for Y4M", frame.BitDepth)
+	}
+}